@@ -4,25 +4,30 @@ import (
 	"errors"
 	"fmt"
 	"go/types"
+	"net/http"
 	"os"
 	"strings"
-	"net/http"
+	"sync"
 
+	"golang.org/x/tools/go/callgraph"
 	"golang.org/x/tools/go/packages"
 	"golang.org/x/tools/go/pointer"
 	"golang.org/x/tools/go/ssa"
 	"golang.org/x/tools/go/ssa/ssautil"
 )
 
-//==[ type def/func: analysis   ]===============================================
+// ==[ type def/func: analysis   ]===============================================
 type renderOpts struct {
-	focus   string
-	group   []string
-	ignore  []string
-	include []string
-	limit   []string
-	nointer bool
-	nostd   bool
+	focus      string
+	group      []string
+	ignore     []string
+	include    []string
+	limit      []string
+	nointer    bool
+	nostd      bool
+	entrypoint string
+	main       string
+	cga        string
 }
 
 // mainPackages returns the main packages to analyze.
@@ -40,26 +45,70 @@ func mainPackages(pkgs []*ssa.Package) ([]*ssa.Package, error) {
 	return mains, nil
 }
 
-//==[ type def/func: analysis   ]===============================================
+// ==[ type def/func: analysis   ]===============================================
 type analysis struct {
 	opts   *renderOpts
 	prog   *ssa.Program
 	pkgs   []*ssa.Package
 	mains  []*ssa.Package
+	cg     *callgraph.Graph
 	result *pointer.Result
+
+	// snapshot is set by ImportSnapshot instead of DoAnalysis, for
+	// `go-callvis serve -from`. When non-nil, Render and the
+	// webui.Provider methods serve it instead of prog/cg/result.
+	snapshot *graphSnapshot
 }
 
 var Analysis *analysis
 
+// analysisCacheEntry holds everything DoAnalysis produces for a given
+// analysisKey, so a repeat request with the same inputs can skip SSA
+// build and call-graph construction entirely.
+type analysisCacheEntry struct {
+	prog   *ssa.Program
+	pkgs   []*ssa.Package
+	mains  []*ssa.Package
+	cg     *callgraph.Graph
+	result *pointer.Result
+}
+
+var (
+	analysisCacheMu  sync.Mutex
+	analysisCacheMap = map[string]*analysisCacheEntry{}
+)
+
 func (a *analysis) DoAnalysis(
 	dir string,
 	tests bool,
+	entry string,
+	cga string,
+	tags string,
 	args []string,
 ) error {
+	key, err := analysisKey(dir, tests, entry, cga, tags, args)
+	if err != nil {
+		return err
+	}
+
+	analysisCacheMu.Lock()
+	cached, ok := analysisCacheMap[key]
+	analysisCacheMu.Unlock()
+	if ok {
+		logf("analysis cache hit: %s", key)
+		a.prog = cached.prog
+		a.pkgs = cached.pkgs
+		a.mains = cached.mains
+		a.cg = cached.cg
+		a.result = cached.result
+		return nil
+	}
+
 	cfg := &packages.Config{
-		Mode:  packages.LoadAllSyntax,
-		Tests: tests,
-		Dir:   dir,
+		Mode:       packages.LoadAllSyntax,
+		Tests:      tests,
+		Dir:        dir,
+		BuildFlags: buildFlagsForTags(tags),
 	}
 
 	initial, err := packages.Load(cfg, args...)
@@ -75,54 +124,56 @@ func (a *analysis) DoAnalysis(
 	prog, pkgs := ssautil.AllPackages(initial, 0)
 	prog.Build()
 
-	mains, err := mainPackages(pkgs)
+	mains, err := resolveMains(pkgs, entry)
 	if err != nil {
-		return err
-	}
-
-	config := &pointer.Config{
-		Mains:          mains,
-		BuildCallGraph: true,
+		// cha and static build whole-program call graphs straight from
+		// prog and never consult mains, so a library with no main
+		// package (the default entry strategy's whole point of failure)
+		// shouldn't stop them. pointer and rta still require mains, so
+		// for those algorithms this error is real and must propagate.
+		if (entry == "" || entry == "mains") && (cga == cgaCHA || cga == cgaStatic) {
+			mains = nil
+		} else {
+			return err
+		}
 	}
 
-	result, err := pointer.Analyze(config)
+	cg, result, err := buildCallGraph(prog, mains, cga)
 	if err != nil {
-		return err // internal error in pointer analysis
-	}
-	//cg.DeleteSyntheticNodes()
-	/*
-	Analysis = &analysis{
-		prog:   prog,
-		pkgs:   pkgs,
-		mains:  mains,
-		result: result,
+		return err
 	}
-	*/
 
-	a.prog   = prog
-	a.pkgs   = pkgs
-	a.mains  = mains
+	a.prog = prog
+	a.pkgs = pkgs
+	a.mains = mains
+	a.cg = cg
 	a.result = result
+
+	analysisCacheMu.Lock()
+	analysisCacheMap[key] = &analysisCacheEntry{prog: prog, pkgs: pkgs, mains: mains, cg: cg, result: result}
+	analysisCacheMu.Unlock()
 	return nil
 }
 
 func (a *analysis) OptsSetup() {
 	a.opts = &renderOpts{
-		focus:   *focusFlag,
-		group:   []string{*groupFlag},
-		ignore:  []string{*ignoreFlag},
-		include: []string{*includeFlag},
-		limit:   []string{*limitFlag},
-		nointer: *nointerFlag,
-		nostd:   *nostdFlag,
+		focus:      *focusFlag,
+		group:      []string{*groupFlag},
+		ignore:     []string{*ignoreFlag},
+		include:    []string{*includeFlag},
+		limit:      []string{*limitFlag},
+		nointer:    *nointerFlag,
+		nostd:      *nostdFlag,
+		entrypoint: *entryFlag,
+		cga:        *cgaFlag,
 	}
 }
 
 func (a *analysis) ProcessListArgs() (e error) {
-	var groupBy      []string
-	var ignorePaths  []string
+	var groupBy []string
+	var ignorePaths []string
 	var includePaths []string
-	var limitPaths   []string
+	var limitPaths []string
 
 	for _, g := range strings.Split(a.r.group[0], ",") {
 		g := strings.TrimSpace(g)
@@ -165,7 +216,7 @@ func (a *analysis) ProcessListArgs() (e error) {
 	return
 }
 
-func (a *analysis) OverrideByHTTP(r *http.Request) () {
+func (a *analysis) OverrideByHTTP(r *http.Request) {
 	if f := r.FormValue("f"); f == "all" {
 		a.opts.focus = ""
 	} else if f != "" {
@@ -189,12 +240,46 @@ func (a *analysis) OverrideByHTTP(r *http.Request) () {
 	if inc := r.FormValue("include"); inc != "" {
 		a.opts.include[0] = inc
 	}
+	if m := r.FormValue("main"); m != "" {
+		a.opts.main = m
+	}
 	return
 }
 
+// pickMain returns the ssa.Package to render from: the one named by
+// opts.main (matched against its import path), if set, else a.mains[0].
+// Multiple mains arise both from normal multi-binary modules and from
+// the library/tests analysis-root strategies (see resolveMains).
+func (a *analysis) pickMain() (*ssa.Package, error) {
+	if a.opts.main == "" {
+		if len(a.mains) == 0 {
+			// cha/static on a library: there is no main to default to,
+			// so fall back to some package to root the rendering at.
+			if len(a.pkgs) == 0 {
+				return nil, fmt.Errorf("render failed: no packages analyzed")
+			}
+			return a.pkgs[0], nil
+		}
+		return a.mains[0], nil
+	}
+	for _, m := range a.mains {
+		if m.Pkg.Path() == a.opts.main {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("main failed, could not find package: %v", a.opts.main)
+}
+
 // basically do printOutput() with previously checking
 // focus option and respective package
 func (a *analysis) Render() ([]byte, error) {
+	if a.snapshot != nil {
+		// Render with the current opts, so OverrideByHTTP changes apply.
+		snap := *a.snapshot
+		snap.Opts = toSnapshotOpts(*a.opts)
+		return snapshotToDOT(&snap)
+	}
+
 	var (
 		err      error
 		ssaPkg   *ssa.Package
@@ -230,10 +315,15 @@ func (a *analysis) Render() ([]byte, error) {
 		logf("focusing: %v", focusPkg.Path())
 	}
 
+	main, err := a.pickMain()
+	if err != nil {
+		return nil, err
+	}
+
 	dot, err := printOutput(
 		a.prog,
-		a.mains[0].Pkg,
-		a.result.CallGraph,
+		main.Pkg,
+		a.cg,
 		focusPkg,
 		a.opts.limit,
 		a.opts.ignore,
@@ -248,4 +338,3 @@ func (a *analysis) Render() ([]byte, error) {
 
 	return dot, nil
 }
-
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// analysisKey identifies everything that can change the result of SSA
+// build + call-graph construction for a given (dir, tests, entry, cga,
+// tags, args): the set of packages and their file contents, the build
+// environment (including build tags), the chosen call-graph algorithm,
+// and the workspace's module files. It deliberately excludes
+// renderOpts: changing focus/group/ignore/include/limit/nostd/nointer
+// never needs to invalidate the cache, only a re-run of Render.
+func analysisKey(dir string, tests bool, entry string, cga string, tags string, args []string) (string, error) {
+	// Cheap metadata-only load: just enough to see which files would be
+	// compiled, without type-checking or building SSA. NeedImports and
+	// NeedDeps are required too, so packages.Visit below can walk past
+	// the root packages matching args into everything they import —
+	// DoAnalysis feeds that whole transitive set to the SSA builder and
+	// pointer analysis, so the key must cover it, not just the roots.
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles |
+			packages.NeedImports | packages.NeedDeps,
+		Tests:      tests,
+		Dir:        dir,
+		BuildFlags: buildFlagsForTags(tags),
+	}
+	initial, err := packages.Load(cfg, args...)
+	if err != nil {
+		return "", err
+	}
+
+	// packages.Visit dedups for us: it visits each package in the import
+	// graph (roots plus every transitive dependency) exactly once.
+	var pkgs []*packages.Package
+	packages.Visit(initial, nil, func(p *packages.Package) {
+		pkgs = append(pkgs, p)
+	})
+
+	h := sha256.New()
+	fmt.Fprintf(h, "goos=%s goarch=%s tests=%t entry=%s cga=%s tags=%s args=%s\n",
+		runtime.GOOS, runtime.GOARCH, tests, entry, cga, tags, strings.Join(args, ","))
+
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].PkgPath < pkgs[j].PkgPath })
+	for _, p := range pkgs {
+		fmt.Fprintf(h, "pkg=%s\n", p.PkgPath)
+		files := append([]string(nil), p.CompiledGoFiles...)
+		sort.Strings(files)
+		for _, f := range files {
+			sum, err := hashFile(f)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(h, "  %s %s\n", f, sum)
+		}
+	}
+
+	if sum, err := hashModFiles(dir); err == nil {
+		fmt.Fprintf(h, "mod=%s\n", sum)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// buildFlagsForTags turns a comma-separated -tags value into the
+// packages.Config.BuildFlags form, or nil when no tags are set.
+func buildFlagsForTags(tags string) []string {
+	if tags == "" {
+		return nil
+	}
+	return []string{"-tags=" + tags}
+}
+
+// fileHashCacheEntry remembers the mtime/size a file had the last time
+// its content was hashed, so a stat that finds nothing changed can
+// avoid reading the file again.
+type fileHashCacheEntry struct {
+	modTime int64
+	size    int64
+	sum     string
+}
+
+var (
+	fileHashMu    sync.Mutex
+	fileHashCache = map[string]fileHashCacheEntry{}
+)
+
+// hashFile returns a sha256 of path's contents. It first stats the
+// file and reuses the cached sum from a previous call if mtime and
+// size are unchanged, so that recomputing analysisKey against a large,
+// unmodified module (the common case in HTTP server mode) costs a
+// stat per file rather than a full content read and hash.
+func hashFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	mtime, size := info.ModTime().UnixNano(), info.Size()
+
+	fileHashMu.Lock()
+	cached, ok := fileHashCache[path]
+	fileHashMu.Unlock()
+	if ok && cached.modTime == mtime && cached.size == size {
+		return cached.sum, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	hexSum := hex.EncodeToString(sum[:])
+
+	fileHashMu.Lock()
+	fileHashCache[path] = fileHashCacheEntry{modTime: mtime, size: size, sum: hexSum}
+	fileHashMu.Unlock()
+
+	return hexSum, nil
+}
+
+// hashModFiles hashes go.mod/go.sum of the module containing dir, if
+// any, so that dependency upgrades also invalidate the cache even when
+// no source file in the analyzed packages changed.
+func hashModFiles(dir string) (string, error) {
+	root, err := findModuleRoot(dir)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	for _, name := range []string{"go.mod", "go.sum"} {
+		data, err := os.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			continue
+		}
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func findModuleRoot(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for d := abs; ; {
+		if _, err := os.Stat(filepath.Join(d, "go.mod")); err == nil {
+			return d, nil
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return "", fmt.Errorf("no go.mod found above %v", abs)
+		}
+		d = parent
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildFlagsForTags(t *testing.T) {
+	if got := buildFlagsForTags(""); got != nil {
+		t.Errorf("buildFlagsForTags(\"\") = %v, want nil", got)
+	}
+	if got, want := buildFlagsForTags("integration"), []string{"-tags=integration"}; len(got) != 1 || got[0] != want[0] {
+		t.Errorf("buildFlagsForTags(\"integration\") = %v, want %v", got, want)
+	}
+}
+
+func TestHashFileReusesCacheUntilModified(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.go")
+	if err := os.WriteFile(path, []byte("package a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := hashFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := hashFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Errorf("hashFile changed with no modification: %q != %q", first, second)
+	}
+
+	// Touch the file with different content; size changes, so the
+	// stat fast path must not reuse the stale cached sum.
+	if err := os.WriteFile(path, []byte("package a\n\nvar X = 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	third, err := hashFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third == first {
+		t.Errorf("hashFile did not pick up content change: got stale sum %q", third)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/callgraph/cha"
+	"golang.org/x/tools/go/callgraph/rta"
+	"golang.org/x/tools/go/callgraph/static"
+	"golang.org/x/tools/go/pointer"
+	"golang.org/x/tools/go/ssa"
+)
+
+// cgaPointer, cgaCHA, cgaRTA and cgaStatic are the valid values of the
+// -cga flag, selecting the call-graph construction algorithm: Andersen
+// pointer analysis (most precise, most expensive), class hierarchy
+// analysis (fast, coarse, whole-program, no main required), rapid type
+// analysis (between the two, needs roots to seed live types) and a
+// purely syntactic static call graph (cheapest, least precise, ignores
+// dynamic dispatch entirely).
+const (
+	cgaPointer = "pointer"
+	cgaCHA     = "cha"
+	cgaRTA     = "rta"
+	cgaStatic  = "static"
+)
+
+// buildCallGraph constructs a *callgraph.Graph for prog using the named
+// algorithm. mains is only consulted by "pointer" (as pointer.Config.Mains)
+// and "rta" (to seed root functions); "cha" and "static" are whole-program
+// and need neither, which is what makes them usable on libraries that
+// have no main package at all.
+//
+// The returned *pointer.Result is non-nil only for the "pointer"
+// algorithm, since it carries points-to information beyond the call
+// graph that nothing else here produces.
+func buildCallGraph(prog *ssa.Program, mains []*ssa.Package, algo string) (*callgraph.Graph, *pointer.Result, error) {
+	switch algo {
+	case "", cgaPointer:
+		config := &pointer.Config{
+			Mains:          mains,
+			BuildCallGraph: true,
+		}
+		result, err := pointer.Analyze(config)
+		if err != nil {
+			return nil, nil, err // internal error in pointer analysis
+		}
+		return result.CallGraph, result, nil
+
+	case cgaCHA:
+		return cha.CallGraph(prog), nil, nil
+
+	case cgaRTA:
+		roots := rtaRoots(mains)
+		if len(roots) == 0 {
+			return nil, nil, fmt.Errorf("rta: no root functions found among mains")
+		}
+		result := rta.Analyze(roots, true)
+		return result.CallGraph, nil, nil
+
+	case cgaStatic:
+		return static.CallGraph(prog), nil, nil
+
+	default:
+		return nil, nil, fmt.Errorf("invalid -cga value: %v", algo)
+	}
+}
+
+// rtaRoots collects the init and main functions of each main package,
+// which is the standard root set for seeding rta.Analyze.
+func rtaRoots(mains []*ssa.Package) []*ssa.Function {
+	var roots []*ssa.Function
+	for _, m := range mains {
+		if m == nil {
+			continue
+		}
+		if fn := m.Func("init"); fn != nil {
+			roots = append(roots, fn)
+		}
+		if fn := m.Func("main"); fn != nil {
+			roots = append(roots, fn)
+		}
+	}
+	return roots
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// exportedEntryPrefix is the prefix of the -entry flag value that selects
+// library mode: "exported:<pkg>" treats every exported function of pkg
+// as a synthetic entry point.
+const exportedEntryPrefix = "exported:"
+
+// resolveMains picks the ssa.Packages to feed to pointer.Config.Mains,
+// according to the analysis root strategy requested via entry:
+//
+//   - ""/"mains"       use the real main packages (mainPackages).
+//   - "exported:<pkg>" synthesize a fake main that calls every exported
+//     function of pkg, so library code can be analyzed without a main.
+//   - "tests"          synthesize a fake main per package that runs its
+//     Test*/Benchmark* functions (requires DoAnalysis to be called with
+//     tests=true so those functions are present in pkgs).
+func resolveMains(pkgs []*ssa.Package, entry string) ([]*ssa.Package, error) {
+	switch {
+	case entry == "" || entry == "mains":
+		return mainPackages(pkgs)
+
+	case entry == "tests":
+		var mains []*ssa.Package
+		for _, p := range pkgs {
+			if p == nil {
+				continue
+			}
+			if main := ssautil.CreateTestMainPackage(p); main != nil {
+				mains = append(mains, main)
+			}
+		}
+		if len(mains) == 0 {
+			return nil, fmt.Errorf("no test functions found")
+		}
+		return mains, nil
+
+	case strings.HasPrefix(entry, exportedEntryPrefix):
+		path := strings.TrimPrefix(entry, exportedEntryPrefix)
+		for _, p := range pkgs {
+			if p != nil && p.Pkg.Path() == path {
+				main, err := synthesizeExportedMain(p)
+				if err != nil {
+					return nil, err
+				}
+				return []*ssa.Package{main}, nil
+			}
+		}
+		return nil, fmt.Errorf("entry package not found: %v", path)
+
+	default:
+		return nil, fmt.Errorf("invalid -entry value: %v", entry)
+	}
+}
+
+// synthesizeExportedMain builds a synthetic "main" ssa.Package whose
+// main function calls every exported, package-level function of pkg
+// with zero-valued arguments, so that pointer.Analyze (which requires
+// at least one real main) can be used to explore a library's behavior.
+//
+// Functions whose parameters mention a type from outside pkg, or an
+// unexported type of pkg, however deeply nested in a
+// struct/interface/func/tuple shape, are skipped: the synthesized source
+// only imports pkg, and such a type may not be nameable (or importable
+// without ambiguity) from here. Result types are not checked, since the
+// results are discarded.
+// Generic functions are skipped too, since a bare type parameter name
+// isn't resolvable outside its own declaration. Zero values are
+// produced with the `*new(T)` idiom, which works regardless of T's
+// kind without us having to print a literal for it.
+func synthesizeExportedMain(pkg *ssa.Package) (*ssa.Package, error) {
+	const alias = "lib"
+	var calls []string
+	scope := pkg.Pkg.Scope()
+	for _, name := range scope.Names() {
+		if !ast.IsExported(name) {
+			continue
+		}
+		fn, ok := scope.Lookup(name).(*types.Func)
+		if !ok {
+			continue
+		}
+		sig := fn.Type().(*types.Signature)
+		if sig.Recv() != nil {
+			continue // methods are reached via their type's entry points, not here
+		}
+		if sig.TypeParams().Len() > 0 {
+			continue // generic: its type parameters aren't nameable in the synthesized file
+		}
+		args, ok := zeroArgExprs(pkg.Pkg, alias, sig)
+		if !ok {
+			continue
+		}
+		calls = append(calls, fmt.Sprintf("\t%s.%s(%s)\n", alias, name, strings.Join(args, ", ")))
+	}
+	if len(calls) == 0 {
+		return nil, fmt.Errorf("no eligible exported functions in %v", pkg.Pkg.Path())
+	}
+
+	src := fmt.Sprintf("package main\n\nimport %s %q\n\nfunc main() {\n%s}\n",
+		alias, pkg.Pkg.Path(), strings.Join(calls, ""))
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "<entry>", src, 0)
+	if err != nil {
+		return nil, fmt.Errorf("internal error synthesizing entry point: %v", err)
+	}
+
+	importer := &singlePackageImporter{pkg: pkg.Pkg}
+	mainPkg := types.NewPackage("command-line-arguments", "main")
+	info := &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Implicits:  make(map[ast.Node]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+	}
+	conf := types.Config{Importer: importer}
+	if err := conf.Check("command-line-arguments", fset, []*ast.File{f}, info); err != nil {
+		return nil, fmt.Errorf("internal error type-checking entry point: %v", err)
+	}
+
+	ssaMain := pkg.Prog.CreatePackage(mainPkg, []*ast.File{f}, info, false)
+	ssaMain.Build()
+	return ssaMain, nil
+}
+
+// zeroArgExprs returns the `*new(T)` argument expressions for sig's
+// parameters, with pkg's types qualified by alias, or ok=false if any
+// parameter type can't be named using only package pkg (i.e. it belongs
+// to some other package or is unexported).
+func zeroArgExprs(pkg *types.Package, alias string, sig *types.Signature) ([]string, bool) {
+	params := sig.Params()
+	n := params.Len()
+	if sig.Variadic() {
+		n-- // call the variadic parameter with zero arguments
+	}
+	qual := func(p *types.Package) string {
+		if p == pkg {
+			return alias
+		}
+		return ""
+	}
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		t := params.At(i).Type()
+		if !namableWithin(pkg, t) {
+			return nil, false
+		}
+		args = append(args, fmt.Sprintf("*new(%s)", types.TypeString(t, qual)))
+	}
+	return args, true
+}
+
+// namableWithin reports whether t only refers to exported types declared
+// in pkg or to types in the universe scope (so it can be written in a
+// file that imports only pkg), recursing into every compound shape that
+// can carry a foreign named type: pointers, slices, arrays, maps,
+// chans, struct fields, interface methods, tuples, and the params/
+// results of a (non-generic) func type.
+func namableWithin(pkg *types.Package, t types.Type) bool {
+	switch t := t.(type) {
+	case *types.Named:
+		obj := t.Obj()
+		return obj.Pkg() == nil || (obj.Pkg() == pkg && obj.Exported())
+	case *types.Pointer:
+		return namableWithin(pkg, t.Elem())
+	case *types.Slice:
+		return namableWithin(pkg, t.Elem())
+	case *types.Array:
+		return namableWithin(pkg, t.Elem())
+	case *types.Map:
+		return namableWithin(pkg, t.Key()) && namableWithin(pkg, t.Elem())
+	case *types.Chan:
+		return namableWithin(pkg, t.Elem())
+	case *types.Signature:
+		if t.TypeParams().Len() > 0 || t.RecvTypeParams().Len() > 0 {
+			return false // generic function type: bare type params aren't nameable here
+		}
+		for i := 0; i < t.Params().Len(); i++ {
+			if !namableWithin(pkg, t.Params().At(i).Type()) {
+				return false
+			}
+		}
+		for i := 0; i < t.Results().Len(); i++ {
+			if !namableWithin(pkg, t.Results().At(i).Type()) {
+				return false
+			}
+		}
+		return true
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			if !namableWithin(pkg, t.Field(i).Type()) {
+				return false
+			}
+		}
+		return true
+	case *types.Interface:
+		for i := 0; i < t.NumMethods(); i++ {
+			if !namableWithin(pkg, t.Method(i).Type()) {
+				return false
+			}
+		}
+		return true
+	case *types.Tuple:
+		for i := 0; i < t.Len(); i++ {
+			if !namableWithin(pkg, t.At(i).Type()) {
+				return false
+			}
+		}
+		return true
+	default:
+		// basic types, etc.
+		return true
+	}
+}
+
+// singlePackageImporter resolves exactly the package it was built for;
+// it is used to type-check the tiny synthetic entry-point file, which
+// only ever imports that one package.
+type singlePackageImporter struct {
+	pkg *types.Package
+}
+
+func (imp *singlePackageImporter) Import(path string) (*types.Package, error) {
+	if path == imp.pkg.Path() {
+		return imp.pkg, nil
+	}
+	return nil, fmt.Errorf("unexpected import in synthesized entry point: %v", path)
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/callgraph"
+)
+
+// snapshotSchemaVersion is bumped whenever the JSON shape of
+// graphSnapshot changes in a way older readers can't cope with, so
+// LoadSnapshot can reject a file it doesn't understand instead of
+// rendering something wrong. `go-callvis export` writes a snapshot;
+// `go-callvis serve -from` reads one back via LoadSnapshot/ImportSnapshot
+// so reviewers can open large graphs without recompiling the module.
+const snapshotSchemaVersion = 1
+
+// graphSnapshot is the on-disk form of an analyzed call graph: enough
+// nodes, edges and render options to reproduce a DOT rendering and to
+// back the web UI's callers/callees lookups without re-running SSA
+// build or the call-graph algorithm.
+type graphSnapshot struct {
+	SchemaVersion int            `json:"schema_version"`
+	Opts          snapshotOpts   `json:"opts"`
+	Mains         []string       `json:"mains"`
+	Nodes         []snapshotNode `json:"nodes"`
+	Edges         []snapshotEdge `json:"edges"`
+}
+
+// snapshotOpts mirrors renderOpts field-for-field with exported,
+// JSON-tagged fields. renderOpts itself stays unexported to match this
+// file set's convention of keeping render state internal, so exporting
+// and importing a snapshot goes through this type's
+// toSnapshotOpts/toRenderOpts conversions instead of marshaling
+// renderOpts directly (encoding/json silently drops unexported fields,
+// which would serialize every snapshot's opts as "{}").
+type snapshotOpts struct {
+	Focus      string   `json:"focus,omitempty"`
+	Group      []string `json:"group,omitempty"`
+	Ignore     []string `json:"ignore,omitempty"`
+	Include    []string `json:"include,omitempty"`
+	Limit      []string `json:"limit,omitempty"`
+	NoInter    bool     `json:"nointer,omitempty"`
+	NoStd      bool     `json:"nostd,omitempty"`
+	Entrypoint string   `json:"entrypoint,omitempty"`
+	Main       string   `json:"main,omitempty"`
+	CGA        string   `json:"cga,omitempty"`
+}
+
+func toSnapshotOpts(o renderOpts) snapshotOpts {
+	return snapshotOpts{
+		Focus:      o.focus,
+		Group:      o.group,
+		Ignore:     o.ignore,
+		Include:    o.include,
+		Limit:      o.limit,
+		NoInter:    o.nointer,
+		NoStd:      o.nostd,
+		Entrypoint: o.entrypoint,
+		Main:       o.main,
+		CGA:        o.cga,
+	}
+}
+
+func (s snapshotOpts) toRenderOpts() renderOpts {
+	return renderOpts{
+		focus:      s.Focus,
+		group:      s.Group,
+		ignore:     s.Ignore,
+		include:    s.Include,
+		limit:      s.Limit,
+		nointer:    s.NoInter,
+		nostd:      s.NoStd,
+		entrypoint: s.Entrypoint,
+		main:       s.Main,
+		cga:        s.CGA,
+	}
+}
+
+// snapshotNode is one call-graph node: a function identified by its
+// RelString(nil) form (the same key findCallGraphNode and the DOT
+// labels use), its declaring package, and its source position for
+// click-through in the web UI.
+type snapshotNode struct {
+	ID   int    `json:"id"`
+	Func string `json:"func"`
+	Pkg  string `json:"pkg,omitempty"`
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+}
+
+// snapshotEdge is one call edge between two snapshotNode IDs, with the
+// call site's source position when known. Synthetic edges (reflection,
+// runtime.SetFinalizer, etc.) have no call site and leave File empty.
+type snapshotEdge struct {
+	Caller int    `json:"caller"`
+	Callee int    `json:"callee"`
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+}
+
+// ExportSnapshot walks the analyzed call graph into a graphSnapshot
+// suitable for JSON serialization. Must be called after DoAnalysis.
+func (a *analysis) ExportSnapshot() (*graphSnapshot, error) {
+	if a.cg == nil {
+		return nil, fmt.Errorf("export failed: no call graph (run DoAnalysis first)")
+	}
+
+	snap := &graphSnapshot{
+		SchemaVersion: snapshotSchemaVersion,
+		Opts:          toSnapshotOpts(*a.opts),
+	}
+	for _, m := range a.mains {
+		snap.Mains = append(snap.Mains, m.Pkg.Path())
+	}
+
+	var nodes []*callgraph.Node
+	for _, n := range a.cg.Nodes {
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodeLabel(nodes[i]) < nodeLabel(nodes[j]) })
+
+	ids := make(map[*callgraph.Node]int, len(nodes))
+	for i, n := range nodes {
+		ids[n] = i
+		sn := snapshotNode{ID: i, Func: nodeLabel(n)}
+		if n.Func != nil {
+			if n.Func.Pkg != nil {
+				sn.Pkg = n.Func.Pkg.Pkg.Path()
+			}
+			if pos := a.prog.Fset.Position(n.Func.Pos()); pos.IsValid() {
+				sn.File, sn.Line = pos.Filename, pos.Line
+			}
+		}
+		snap.Nodes = append(snap.Nodes, sn)
+	}
+
+	for _, n := range nodes {
+		for _, e := range n.Out {
+			se := snapshotEdge{Caller: ids[n], Callee: ids[e.Callee]}
+			if e.Site != nil {
+				if pos := a.prog.Fset.Position(e.Site.Pos()); pos.IsValid() {
+					se.File, se.Line = pos.Filename, pos.Line
+				}
+			}
+			snap.Edges = append(snap.Edges, se)
+		}
+	}
+
+	return snap, nil
+}
+
+// nodeLabel is the RelString(nil) form used to key nodes, matching
+// findCallGraphNode and the existing DOT node labels. The synthetic
+// root node (Func == nil) gets a fixed placeholder.
+func nodeLabel(n *callgraph.Node) string {
+	if n.Func == nil {
+		return "<root>"
+	}
+	return n.Func.RelString(nil)
+}
+
+// WriteSnapshot exports a's call graph and writes it to w as indented
+// JSON, for `go-callvis export -o graph.json`.
+func (a *analysis) WriteSnapshot(w io.Writer) error {
+	snap, err := a.ExportSnapshot()
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(snap)
+}
+
+// LoadSnapshot reads and validates a graphSnapshot previously written by
+// WriteSnapshot, for `go-callvis serve -from graph.json`.
+func LoadSnapshot(r io.Reader) (*graphSnapshot, error) {
+	var snap graphSnapshot
+	if err := json.NewDecoder(r).Decode(&snap); err != nil {
+		return nil, fmt.Errorf("snapshot decode failed: %v", err)
+	}
+	if snap.SchemaVersion != snapshotSchemaVersion {
+		return nil, fmt.Errorf("unsupported snapshot schema version %d (want %d)", snap.SchemaVersion, snapshotSchemaVersion)
+	}
+	return &snap, nil
+}
+
+// ImportSnapshot installs snap as a's data source, so Render and the
+// webui.Provider methods serve it without any *ssa.Program or
+// *callgraph.Graph in memory. MethodSet is the one Provider method that
+// genuinely needs live go/types info and returns an error in this mode.
+func (a *analysis) ImportSnapshot(snap *graphSnapshot) {
+	a.snapshot = snap
+	opts := snap.Opts.toRenderOpts()
+	a.opts = &opts
+}
+
+// snapshotNodeByFunc looks up a snapshot node by its Func label, the
+// same key used by findCallGraphNode against a live call graph.
+func (a *analysis) snapshotNodeByFunc(fn string) (*snapshotNode, bool) {
+	for i := range a.snapshot.Nodes {
+		if a.snapshot.Nodes[i].Func == fn {
+			return &a.snapshot.Nodes[i], true
+		}
+	}
+	return nil, false
+}
+
+func (a *analysis) snapshotCallersOf(fn string) ([]string, error) {
+	n, ok := a.snapshotNodeByFunc(fn)
+	if !ok {
+		return nil, fmt.Errorf("callgraph lookup failed, unknown function: %v", fn)
+	}
+	var callers []string
+	for _, e := range a.snapshot.Edges {
+		if e.Callee == n.ID {
+			callers = append(callers, a.snapshot.Nodes[e.Caller].Func)
+		}
+	}
+	return callers, nil
+}
+
+func (a *analysis) snapshotCalleesOf(fn string) ([]string, error) {
+	n, ok := a.snapshotNodeByFunc(fn)
+	if !ok {
+		return nil, fmt.Errorf("callgraph lookup failed, unknown function: %v", fn)
+	}
+	var callees []string
+	for _, e := range a.snapshot.Edges {
+		if e.Caller == n.ID {
+			callees = append(callees, a.snapshot.Nodes[e.Callee].Func)
+		}
+	}
+	return callees, nil
+}
+
+func (a *analysis) snapshotPosition(fn string) (file string, line int, ok bool) {
+	n, found := a.snapshotNodeByFunc(fn)
+	if !found || n.File == "" {
+		return "", 0, false
+	}
+	return n.File, n.Line, true
+}
+
+// snapshotToDOT renders a minimal Graphviz DOT document directly from a
+// graphSnapshot. There is no *ssa.Program to drive the full printOutput
+// pipeline in this mode, so grouping (by package or by type) and the
+// richer node styling are not reproduced; focus/ignore/include/limit/nostd are honored
+// against the snapshot's plain package strings.
+func snapshotToDOT(snap *graphSnapshot) ([]byte, error) {
+	opts := snap.Opts.toRenderOpts()
+	keep := make(map[int]bool, len(snap.Nodes))
+	for _, n := range snap.Nodes {
+		if snapshotNodeVisible(n, opts) {
+			keep[n.ID] = true
+		}
+	}
+
+	var b bytes.Buffer
+	b.WriteString("digraph callgraph {\n")
+	for _, n := range snap.Nodes {
+		if keep[n.ID] {
+			fmt.Fprintf(&b, "  n%d [label=%q];\n", n.ID, n.Func)
+		}
+	}
+	for _, e := range snap.Edges {
+		if keep[e.Caller] && keep[e.Callee] {
+			fmt.Fprintf(&b, "  n%d -> n%d;\n", e.Caller, e.Callee)
+		}
+	}
+	b.WriteString("}\n")
+	return b.Bytes(), nil
+}
+
+// snapshotNodeVisible reports whether n passes opts' filters. include is
+// an allowlist of package prefixes that are kept regardless of what
+// nostd/limit would otherwise exclude them for — the same carve-out
+// role it plays against a live *ssa.Program — but it never overrides an
+// explicit ignore match.
+func snapshotNodeVisible(n snapshotNode, opts renderOpts) bool {
+	included := false
+	for _, pat := range opts.include {
+		if pat != "" && strings.HasPrefix(n.Pkg, pat) {
+			included = true
+			break
+		}
+	}
+
+	if opts.nostd && isStdPkg(n.Pkg) && !included {
+		return false
+	}
+	if opts.focus != "" && n.Pkg != opts.focus {
+		return false
+	}
+	for _, pat := range opts.ignore {
+		if pat != "" && strings.Contains(n.Pkg, pat) {
+			return false
+		}
+	}
+	for _, pat := range opts.limit {
+		if pat != "" && !strings.HasPrefix(n.Pkg, pat) && !included {
+			return false
+		}
+	}
+	return true
+}
+
+// isStdPkg heuristically identifies standard-library packages: their
+// import path's first segment has no dot, unlike "github.com/...".
+func isStdPkg(pkg string) bool {
+	if pkg == "" {
+		return false
+	}
+	first := pkg
+	if i := strings.Index(pkg, "/"); i >= 0 {
+		first = pkg[:i]
+	}
+	return !strings.Contains(first, ".")
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+)
+
+func TestSnapshotOptsRoundTrip(t *testing.T) {
+	opts := renderOpts{
+		focus:      "example.com/pkg",
+		group:      []string{"pkg"},
+		ignore:     []string{"vendor"},
+		include:    []string{"example.com/pkg/internal"},
+		limit:      []string{"example.com"},
+		nointer:    true,
+		nostd:      true,
+		entrypoint: "tests",
+		main:       "example.com/cmd",
+		cga:        cgaCHA,
+	}
+
+	got := toSnapshotOpts(opts).toRenderOpts()
+	if !reflect.DeepEqual(got, opts) {
+		t.Errorf("toRenderOpts(toSnapshotOpts(opts)) = %+v, want %+v", got, opts)
+	}
+}
+
+func TestWriteLoadSnapshotRoundTrip(t *testing.T) {
+	a := &analysis{
+		cg:   &callgraph.Graph{Nodes: make(map[*ssa.Function]*callgraph.Node)},
+		opts: &renderOpts{focus: "example.com/pkg", nostd: true},
+	}
+
+	var buf bytes.Buffer
+	if err := a.WriteSnapshot(&buf); err != nil {
+		t.Fatalf("WriteSnapshot: %v", err)
+	}
+
+	snap, err := LoadSnapshot(&buf)
+	if err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+	if snap.Opts.Focus != "example.com/pkg" || !snap.Opts.NoStd {
+		t.Errorf("round-tripped opts = %+v, want focus/nostd preserved", snap.Opts)
+	}
+}
+
+func TestLoadSnapshotRejectsUnknownSchemaVersion(t *testing.T) {
+	_, err := LoadSnapshot(strings.NewReader(`{"schema_version": 99}`))
+	if err == nil {
+		t.Fatal("LoadSnapshot accepted an unsupported schema version")
+	}
+}
+
+func TestExportSnapshotRequiresCallGraph(t *testing.T) {
+	a := &analysis{opts: &renderOpts{}}
+	if _, err := a.ExportSnapshot(); err == nil {
+		t.Fatal("ExportSnapshot succeeded with no call graph")
+	}
+}
+
+func TestSnapshotNodeVisible(t *testing.T) {
+	tests := []struct {
+		name string
+		pkg  string
+		opts renderOpts
+		want bool
+	}{
+		{"no filters", "example.com/pkg", renderOpts{}, true},
+		{"nostd drops stdlib", "fmt", renderOpts{nostd: true}, false},
+		{"nostd keeps non-stdlib", "example.com/pkg", renderOpts{nostd: true}, true},
+		{"focus mismatch excluded", "example.com/other", renderOpts{focus: "example.com/pkg"}, false},
+		{"focus match included", "example.com/pkg", renderOpts{focus: "example.com/pkg"}, true},
+		{"ignore match excluded", "example.com/pkg/internal", renderOpts{ignore: []string{"internal"}}, false},
+		{"limit prefix mismatch excluded", "other.com/pkg", renderOpts{limit: []string{"example.com"}}, false},
+		{"limit prefix match included", "example.com/pkg", renderOpts{limit: []string{"example.com"}}, true},
+		{
+			name: "include overrides nostd for stdlib",
+			pkg:  "fmt",
+			opts: renderOpts{nostd: true, include: []string{"fmt"}},
+			want: true,
+		},
+		{
+			name: "include overrides limit mismatch",
+			pkg:  "other.com/pkg",
+			opts: renderOpts{limit: []string{"example.com"}, include: []string{"other.com"}},
+			want: true,
+		},
+		{
+			name: "include does not override an explicit ignore",
+			pkg:  "example.com/pkg/internal",
+			opts: renderOpts{ignore: []string{"internal"}, include: []string{"example.com"}},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := snapshotNode{Pkg: tt.pkg}
+			if got := snapshotNodeVisible(n, tt.opts); got != tt.want {
+				t.Errorf("snapshotNodeVisible(%q, %+v) = %v, want %v", tt.pkg, tt.opts, got, tt.want)
+			}
+		})
+	}
+}
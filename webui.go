@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/suntong/go-callvis/webui"
+	"golang.org/x/tools/go/callgraph"
+)
+
+//==[ type def/func: analysis -> webui.Provider ]================================
+
+// CallersOf implements webui.Provider.
+func (a *analysis) CallersOf(fn string) ([]string, error) {
+	if a.snapshot != nil {
+		return a.snapshotCallersOf(fn)
+	}
+	node, err := a.findCallGraphNode(fn)
+	if err != nil {
+		return nil, err
+	}
+	var callers []string
+	for _, edge := range node.In {
+		callers = append(callers, edge.Caller.Func.RelString(nil))
+	}
+	return callers, nil
+}
+
+// CalleesOf implements webui.Provider.
+func (a *analysis) CalleesOf(fn string) ([]string, error) {
+	if a.snapshot != nil {
+		return a.snapshotCalleesOf(fn)
+	}
+	node, err := a.findCallGraphNode(fn)
+	if err != nil {
+		return nil, err
+	}
+	var callees []string
+	for _, edge := range node.Out {
+		callees = append(callees, edge.Callee.Func.RelString(nil))
+	}
+	return callees, nil
+}
+
+// MethodSet implements webui.Provider, listing the methods in the
+// method set of the named type (as found among the analyzed packages).
+// Unavailable against an imported snapshot, which carries no go/types
+// info beyond plain package-path strings.
+func (a *analysis) MethodSet(typ string) ([]string, error) {
+	if a.snapshot != nil {
+		return nil, fmt.Errorf("methodset unavailable: analysis loaded from a snapshot")
+	}
+	for _, pkg := range a.pkgs {
+		if obj := pkg.Pkg.Scope().Lookup(typ); obj != nil {
+			mset := pkg.Prog.MethodSets.MethodSet(obj.Type())
+			var methods []string
+			for i := 0; i < mset.Len(); i++ {
+				methods = append(methods, mset.At(i).Obj().Name())
+			}
+			return methods, nil
+		}
+	}
+	return nil, fmt.Errorf("methodset failed, could not find type: %v", typ)
+}
+
+// Position implements webui.Provider using the SSA function's
+// source position, as reported by the program's token.FileSet.
+func (a *analysis) Position(fn string) (file string, line int, ok bool) {
+	if a.snapshot != nil {
+		return a.snapshotPosition(fn)
+	}
+	node, err := a.findCallGraphNode(fn)
+	if err != nil || node.Func == nil {
+		return "", 0, false
+	}
+	pos := a.prog.Fset.Position(node.Func.Pos())
+	if !pos.IsValid() {
+		return "", 0, false
+	}
+	return pos.Filename, pos.Line, true
+}
+
+// findCallGraphNode looks up the call graph node for the function whose
+// RelString matches fn (the same form used to label graph nodes).
+func (a *analysis) findCallGraphNode(fn string) (*callgraph.Node, error) {
+	for f, node := range a.cg.Nodes {
+		if f != nil && f.RelString(nil) == fn {
+			return node, nil
+		}
+	}
+	return nil, fmt.Errorf("callgraph lookup failed, unknown function: %v", fn)
+}
+
+// ServeWebUI starts the interactive web UI on addr, serving the graph as
+// SVG plus the /api/callers, /api/callees and /api/methodset endpoints.
+func (a *analysis) ServeWebUI(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/", webui.New(a).Handler())
+	mux.HandleFunc("/graph.svg", a.handleGraphSVG)
+	logf("serving web UI at http://%s", addr)
+	return http.ListenAndServe(addr, mux)
+}
+
+func (a *analysis) handleGraphSVG(w http.ResponseWriter, r *http.Request) {
+	a.OverrideByHTTP(r)
+	dot, err := a.Render()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	svg, err := dotToImage("", "svg", dot)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.ServeFile(w, r, svg)
+}
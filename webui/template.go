@@ -0,0 +1,84 @@
+package webui
+
+import "html/template"
+
+// tmplIndex renders the interactive graph page: an <object> embedding the
+// SVG produced by Render, plus a side panel driven by the /api/callers,
+// /api/callees and /api/methodset endpoints. Clicking a node shows its
+// callers, shift-clicking shows its callees, and clicking any function
+// in the resulting list re-fetches /graph.svg?f=<pkg> to pivot the
+// rendered graph onto that function's package, so browsing jumps
+// between packages instead of only listing cross-references to read.
+var tmplIndex = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>go-callvis</title>
+<style>
+  body { margin: 0; font-family: sans-serif; display: flex; height: 100vh; }
+  #graph { flex: 1; overflow: auto; }
+  #panel { width: 320px; border-left: 1px solid #ccc; padding: 8px; overflow: auto; }
+  #panel h3 { margin-top: 0; }
+  #panel ul { list-style: none; padding-left: 0; }
+  #panel li { padding: 2px 0; cursor: pointer; }
+  #panel li:hover { text-decoration: underline; }
+  #methodset { display: flex; gap: 4px; margin-bottom: 8px; }
+  #methodset input { flex: 1; min-width: 0; }
+</style>
+</head>
+<body>
+  <div id="graph"><object data="/graph.svg" type="image/svg+xml"></object></div>
+  <div id="panel">
+    <form id="methodset">
+      <input type="text" placeholder="type name" aria-label="type name">
+      <button type="submit">Method set</button>
+    </form>
+    <p>Click a function node for its callers, shift-click for its callees.
+    Click any name below to pivot the graph onto its package.</p>
+  </div>
+  <script>
+  function pkgOfFunc(name) {
+    var m = /^\(\*?([^)]*)\)\.[^.]+$/.exec(name);
+    if (m) {
+      var i = m[1].lastIndexOf('.');
+      return i >= 0 ? m[1].slice(0, i) : m[1];
+    }
+    return name.replace(/\.[^.]+$/, '');
+  }
+  function pivot(name) {
+    var obj = document.querySelector('#graph object');
+    obj.data = '/graph.svg?f=' + encodeURIComponent(pkgOfFunc(name));
+  }
+  function show(title, refs) {
+    var panel = document.getElementById('panel');
+    var html = '<h3>' + title + '</h3><ul>';
+    refs.forEach(function(r) {
+      var loc = r.file ? (' <small>' + r.file + ':' + r.line + '</small>') : '';
+      html += '<li data-name="' + r.name + '">' + r.name + loc + '</li>';
+    });
+    panel.innerHTML = html + '</ul>';
+    panel.querySelectorAll('li[data-name]').forEach(function(li) {
+      li.addEventListener('click', function() { pivot(li.dataset.name); });
+    });
+  }
+  function query(kind, param, name) {
+    fetch('/api/' + kind + '?' + param + '=' + encodeURIComponent(name))
+      .then(function(resp) { return resp.json(); })
+      .then(function(refs) { show(kind + ' of ' + name, refs); });
+  }
+  document.addEventListener('click', function(ev) {
+    var node = ev.target.closest('[id^="node"]');
+    if (!node) return;
+    var label = node.querySelector('text');
+    if (!label) return;
+    query(ev.shiftKey ? 'callees' : 'callers', 'func', label.textContent);
+  });
+  document.getElementById('methodset').addEventListener('submit', function(ev) {
+    ev.preventDefault();
+    var input = ev.target.querySelector('input');
+    if (input.value) query('methodset', 'type', input.value);
+  });
+  </script>
+</body>
+</html>
+`))
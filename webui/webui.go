@@ -0,0 +1,128 @@
+// Package webui implements the interactive, browser-based callgraph
+// viewer. It knows nothing about SSA, pointer analysis or DOT rendering
+// directly: it talks to the rest of go-callvis through the Provider
+// interface, so the analysis package can stay import-free of net/http
+// concerns and webui can be reused by other callers of the Provider API.
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Provider is the read-only view of an analyzed call graph that the web
+// UI needs in order to answer navigation and cross-reference requests.
+// *analysis (see analysis.go) implements this interface.
+type Provider interface {
+	// Render returns the DOT source for the graph as currently focused.
+	Render() ([]byte, error)
+
+	// CallersOf returns the display names of the functions that call fn.
+	CallersOf(fn string) ([]string, error)
+
+	// CalleesOf returns the display names of the functions called by fn.
+	CalleesOf(fn string) ([]string, error)
+
+	// MethodSet returns the methods in the method set of the named type.
+	MethodSet(typ string) ([]string, error)
+
+	// Position returns the source location of fn, if known.
+	Position(fn string) (file string, line int, ok bool)
+}
+
+// Server serves the interactive callgraph web UI and its JSON API on
+// top of a Provider.
+type Server struct {
+	provider Provider
+}
+
+// New creates a Server backed by the given Provider.
+func New(p Provider) *Server {
+	return &Server{provider: p}
+}
+
+// Handler returns the http.Handler for the web UI and its API endpoints,
+// ready to be mounted on an *http.ServeMux or passed to http.ListenAndServe.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handleIndex)
+	mux.HandleFunc("/api/callers", s.handleCallers)
+	mux.HandleFunc("/api/callees", s.handleCallees)
+	mux.HandleFunc("/api/methodset", s.handleMethodSet)
+	return mux
+}
+
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if err := tmplIndex.Execute(w, nil); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// funcRef is the JSON shape returned for each caller/callee in the API,
+// carrying enough position info for the UI to jump to source.
+type funcRef struct {
+	Name string `json:"name"`
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+}
+
+func (s *Server) resolveRefs(names []string) []funcRef {
+	refs := make([]funcRef, len(names))
+	for i, name := range names {
+		ref := funcRef{Name: name}
+		if file, line, ok := s.provider.Position(name); ok {
+			ref.File, ref.Line = file, line
+		}
+		refs[i] = ref
+	}
+	return refs
+}
+
+func (s *Server) handleCallers(w http.ResponseWriter, r *http.Request) {
+	fn := r.FormValue("func")
+	if fn == "" {
+		http.Error(w, "missing func parameter", http.StatusBadRequest)
+		return
+	}
+	names, err := s.provider.CallersOf(fn)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, s.resolveRefs(names))
+}
+
+func (s *Server) handleCallees(w http.ResponseWriter, r *http.Request) {
+	fn := r.FormValue("func")
+	if fn == "" {
+		http.Error(w, "missing func parameter", http.StatusBadRequest)
+		return
+	}
+	names, err := s.provider.CalleesOf(fn)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, s.resolveRefs(names))
+}
+
+func (s *Server) handleMethodSet(w http.ResponseWriter, r *http.Request) {
+	typ := r.FormValue("type")
+	if typ == "" {
+		http.Error(w, "missing type parameter", http.StatusBadRequest)
+		return
+	}
+	names, err := s.provider.MethodSet(typ)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, s.resolveRefs(names))
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}